@@ -3,18 +3,366 @@ package provider
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"os"
 	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/redhatinsights/sources-superkey-worker/amazon"
 	l "github.com/redhatinsights/sources-superkey-worker/logger"
+	"github.com/redhatinsights/sources-superkey-worker/provider/journal"
+	"github.com/redhatinsights/sources-superkey-worker/provider/lease"
+	"github.com/redhatinsights/sources-superkey-worker/provider/policylint"
 	"github.com/redhatinsights/sources-superkey-worker/superkey"
 )
 
+// permissionsBoundaryEnvVar is the fleet-wide default permissions boundary
+// ARN, used unless a request overrides it via Extra["permissions_boundary_arn"].
+const permissionsBoundaryEnvVar = "SUPERKEY_PERMISSIONS_BOUNDARY_ARN"
+
+// defaultLeaseTTL is how long an assumed-role STS credential is requested for
+// when the request does not specify its own `lease_ttl` (in seconds).
+const defaultLeaseTTL = time.Hour
+
+// resolveLeaseTTL reads the request's `lease_ttl` override (in seconds), if
+// any, and clamps it to the role's own MaxSessionDuration so we never ask
+// STS for a session longer than the role allows.
+func (a *AmazonProvider) resolveLeaseTTL(f *superkey.ForgedApplication, roleArn string) time.Duration {
+	ttl := defaultLeaseTTL
+
+	if raw := f.Request.Extra["lease_ttl"]; raw != "" {
+		if seconds, err := strconv.Atoi(raw); err == nil && seconds > 0 {
+			ttl = time.Duration(seconds) * time.Second
+		} else {
+			l.Log.Warnf("Ignoring invalid lease_ttl %q for request %v, using default %v", raw, f.Request, defaultLeaseTTL)
+		}
+	}
+
+	maxDuration, err := a.Client.GetRoleMaxSessionDuration(roleArn)
+	if err != nil {
+		l.Log.Warnf("Failed to look up MaxSessionDuration for role %v, using requested TTL %v: %v", roleArn, ttl, err)
+		return ttl
+	}
+
+	if ttl > maxDuration {
+		l.Log.Infof("Clamping lease TTL for role %v from %v to its MaxSessionDuration %v", roleArn, ttl, maxDuration)
+		ttl = maxDuration
+	}
+
+	return ttl
+}
+
 // AmazonProvider struct for implementing the Amazon Provider interface
 type AmazonProvider struct {
 	Client *amazon.Client
+	// Leases tracks and renews any short-lived STS credentials issued while
+	// forging applications. It is safe for concurrent use, and nil-able for
+	// callers that never request `assume_role_credentials`.
+	Leases *lease.Manager
+	// Journal records completed forge steps so a crash between two AWS
+	// calls can be detected and reconciled on the next worker startup. A
+	// nil Journal disables crash recovery, it does not skip any AWS calls.
+	Journal journal.Journal
+	// CredentialPublisher republishes rotated STS credentials over the
+	// Kafka topic the worker consumes superkey requests from, so Sources
+	// can update the authentication record on every lease renewal. A nil
+	// publisher means rotations happen but are never delivered anywhere.
+	CredentialPublisher lease.Publisher
+	// WildcardActionAllowlist lists application types (matched against
+	// request.ApplicationType) that are exempt from policylint's
+	// Action:*/Resource:* rejection, for the rare application that
+	// legitimately needs it.
+	WildcardActionAllowlist []string
+	// ActionAllowlist, when non-empty, is the full set of IAM actions
+	// policylint permits a SuperKey policy document to request. Any action
+	// outside it is rejected, regardless of the resource it's scoped to.
+	ActionAllowlist []string
+}
+
+// boundaryArn resolves the permissions boundary to attach to every role this
+// provider creates: a per-request override takes precedence over the
+// fleet-wide default configured via SUPERKEY_PERMISSIONS_BOUNDARY_ARN.
+func boundaryArn(f *superkey.ForgedApplication) string {
+	if override := f.Request.Extra["permissions_boundary_arn"]; override != "" {
+		return override
+	}
+
+	return os.Getenv(permissionsBoundaryEnvVar)
+}
+
+// attachPermissionsBoundary puts the resolved permissions boundary on a
+// freshly created role and records it in StepsCompleted so TearDown knows to
+// detach it before the role itself is destroyed. The boundary is mandatory:
+// it is the hard ceiling on what a superkey-created role can ever do, so a
+// role is never left without one - if neither a per-request override nor
+// SUPERKEY_PERMISSIONS_BOUNDARY_ARN is configured, the forge fails instead
+// of silently creating an unbounded role.
+func (a *AmazonProvider) attachPermissionsBoundary(f *superkey.ForgedApplication, roleName string) error {
+	arn := boundaryArn(f)
+	if arn == "" {
+		return fmt.Errorf("no permissions boundary configured for request %v: set Extra[\"permissions_boundary_arn\"] or %v", f.Request, permissionsBoundaryEnvVar)
+	}
+
+	if err := a.Client.PutRolePermissionsBoundary(roleName, arn); err != nil {
+		return err
+	}
+
+	a.markCompleted(f, "attach_boundary", map[string]string{"output": arn})
+	l.Log.Infof("Attached permissions boundary %v to role %v", arn, roleName)
+	return nil
+}
+
+// requestIdentifier returns the id of the superkey request itself (as
+// assigned by Sources), falling back to the application type if the request
+// didn't carry one - it is never empty, since Reconcile/the journal key off
+// of it alongside the tenant.
+func requestIdentifier(f *superkey.ForgedApplication) string {
+	if id := f.Request.Extra["request_id"]; id != "" {
+		return id
+	}
+	return f.Request.ApplicationType
+}
+
+// resourceTags are applied to every AWS resource ForgeApplication creates, so
+// Reconcile can find and attribute them independently of the journal. The
+// tenant tag matters as much as the GUID: Reconcile/the journal are both
+// keyed by (tenant, guid), and a resource with no tenant tag can never be
+// looked up again.
+func resourceTags(f *superkey.ForgedApplication) map[string]string {
+	return map[string]string{
+		"red-hat-superkey-guid":    f.GUID,
+		"red-hat-superkey-tenant":  f.Request.Extra["account"],
+		"red-hat-superkey-request": requestIdentifier(f),
+	}
+}
+
+// redactedSecretFields are stripped out of any StepsCompleted map before it
+// is persisted to the Journal - the journal is meant to survive a crash, not
+// to become a second place long-lived copies of short-lived secrets live.
+var redactedSecretFields = []string{"secret_access_key", "session_token"}
+
+const redactedPlaceholder = "[redacted]"
+
+// redactForJournal deep-copies steps, blanking out secret material so it is
+// never written to durable storage in plaintext.
+func redactForJournal(steps map[string]map[string]string) map[string]map[string]string {
+	redacted := make(map[string]map[string]string, len(steps))
+	for step, data := range steps {
+		copied := make(map[string]string, len(data))
+		for k, v := range data {
+			copied[k] = v
+		}
+		for _, field := range redactedSecretFields {
+			if _, ok := copied[field]; ok {
+				copied[field] = redactedPlaceholder
+			}
+		}
+		redacted[step] = copied
+	}
+	return redacted
+}
+
+// resumePolicyFor reads the request's `resume_policy` override, defaulting
+// to tearing the forge down if the worker restarts mid-way through it.
+func resumePolicyFor(f *superkey.ForgedApplication) journal.ResumePolicy {
+	switch journal.ResumePolicy(f.Request.Extra["resume_policy"]) {
+	case journal.ResumeContinue:
+		return journal.ResumeContinue
+	default:
+		return journal.ResumeTearDown
+	}
+}
+
+// marshalRequest serializes the original request so a ResumeContinue replay
+// can pick the forge back up without the originating Kafka message.
+func marshalRequest(f *superkey.ForgedApplication) json.RawMessage {
+	raw, err := json.Marshal(f.Request)
+	if err != nil {
+		l.Log.Warnf("Failed to serialize request %v for the journal: %v", f.Request, err)
+		return nil
+	}
+	return raw
+}
+
+// markCompleted records a finished step on the in-memory ForgedApplication
+// and, if a.Journal is configured, persists it so the step survives a worker
+// restart.
+func (a *AmazonProvider) markCompleted(f *superkey.ForgedApplication, step string, data map[string]string) {
+	f.MarkCompleted(step, data)
+
+	if a.Journal == nil {
+		return
+	}
+
+	tenant := f.Request.Extra["account"]
+	err := a.Journal.Put(journal.Entry{
+		Tenant:         tenant,
+		RequestID:      requestIdentifier(f),
+		GUID:           f.GUID,
+		Status:         journal.StatusInProgress,
+		StepsCompleted: redactForJournal(f.StepsCompleted),
+		ResumePolicy:   resumePolicyFor(f),
+		Request:        marshalRequest(f),
+	})
+	if err != nil {
+		l.Log.Warnf("Failed to journal step %v for request %v: %v", step, f.GUID, err)
+	}
+}
+
+// journalRotatedCredentials persists a renewed STS lease to the Journal
+// without touching the live superkey.ForgedApplication - this runs on the
+// lease Manager's background renewal goroutine, which must never read or
+// write f.StepsCompleted concurrently with the goroutine driving
+// ForgeApplication/TearDown.
+func (a *AmazonProvider) journalRotatedCredentials(tenant, requestID, guid string, creds *amazon.STSCredentials) {
+	if a.Journal == nil {
+		return
+	}
+
+	entry, ok, err := a.Journal.Get(tenant, guid)
+	if err != nil {
+		l.Log.Warnf("Failed to load journal entry for rotated lease %v: %v", guid, err)
+		return
+	}
+	if !ok {
+		entry = journal.Entry{
+			Tenant:         tenant,
+			RequestID:      requestID,
+			GUID:           guid,
+			Status:         journal.StatusInProgress,
+			StepsCompleted: make(map[string]map[string]string),
+		}
+	}
+	if entry.StepsCompleted == nil {
+		entry.StepsCompleted = make(map[string]map[string]string)
+	}
+
+	entry.StepsCompleted["assume_role_credentials"] = map[string]string{
+		"access_key_id":     creds.AccessKeyID,
+		"secret_access_key": redactedPlaceholder,
+		"session_token":     redactedPlaceholder,
+		"expiration":        creds.Expiration.Format(time.RFC3339),
+	}
+
+	if err := a.Journal.Put(entry); err != nil {
+		l.Log.Warnf("Failed to journal rotated lease %v: %v", guid, err)
+	}
+}
+
+// Reconcile lists every AWS resource tagged with our superkey GUID tag and
+// cross-checks it against the journal, tearing down anything older than
+// gracePeriod whose GUID the journal no longer knows about, or whose journal
+// entry is `torn_down` (a TearDown that left resources behind). It is meant to
+// be run periodically, independent of any single forge/teardown call.
+func (a *AmazonProvider) Reconcile(gracePeriod time.Duration) []error {
+	errors := make([]error, 0)
+
+	tagged, err := a.Client.ListTaggedResources("red-hat-superkey-guid", gracePeriod)
+	if err != nil {
+		return append(errors, err)
+	}
+
+	for _, resource := range tagged {
+		if a.Journal != nil {
+			if entry, ok, _ := a.Journal.Get(resource.Tenant, resource.GUID); ok && entry.Status != journal.StatusTornDown {
+				continue
+			}
+		}
+
+		l.Log.Warnf("Reconcile: orphaned resource %v (guid %v) has no journal entry, deleting", resource.ARN, resource.GUID)
+		if err := a.Client.DestroyTaggedResource(resource); err != nil {
+			l.Log.Warnf("Reconcile: failed to delete orphaned resource %v: %v", resource.ARN, err)
+			errors = append(errors, err)
+		}
+	}
+
+	return errors
+}
+
+// Resume replays every `in_progress` journal entry, meant to be called once
+// on worker startup before the worker starts consuming new superkey
+// requests off Kafka. An entry whose resume_policy is ResumeContinue is
+// picked back up from its last completed step; anything else (including an
+// entry with no recorded resume_policy) is torn down, since we'd otherwise
+// be guessing at how far a half-forged application got.
+func (a *AmazonProvider) Resume() []error {
+	errors := make([]error, 0)
+
+	if a.Journal == nil {
+		return errors
+	}
+
+	entries, err := a.Journal.InProgress()
+	if err != nil {
+		return append(errors, err)
+	}
+
+	for _, entry := range entries {
+		if entry.ResumePolicy == journal.ResumeContinue {
+			f, err := resumeForge(entry)
+			if err != nil {
+				l.Log.Errorf("Failed to resume request %v, tearing it down instead: %v", entry.GUID, err)
+				errors = append(errors, a.tearDownEntry(entry)...)
+				continue
+			}
+
+			l.Log.Infof("Resuming in-progress request %v from its last completed step", entry.GUID)
+			if _, err := a.forge(f); err != nil {
+				errors = append(errors, err)
+			}
+			continue
+		}
+
+		l.Log.Infof("Tearing down in-progress request %v left behind by a worker restart", entry.GUID)
+		errors = append(errors, a.tearDownEntry(entry)...)
+	}
+
+	return errors
+}
+
+// resumeForge rebuilds the ForgedApplication a journal entry was tracking,
+// pre-seeded with the steps it already completed, so `forge` can pick up
+// where the worker left off.
+func resumeForge(entry journal.Entry) (*superkey.ForgedApplication, error) {
+	if len(entry.Request) == 0 {
+		return nil, fmt.Errorf("no journalled request body for %v, cannot resume", entry.GUID)
+	}
+
+	var request superkey.CreateRequest
+	if err := json.Unmarshal(entry.Request, &request); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal journalled request for %v: %w", entry.GUID, err)
+	}
+
+	return &superkey.ForgedApplication{
+		StepsCompleted: entry.StepsCompleted,
+		Request:        &request,
+		GUID:           entry.GUID,
+	}, nil
+}
+
+// tearDownEntry reconstructs the minimal ForgedApplication TearDown needs
+// from a journal entry and tears it down. It falls back to a bare-bones
+// request built from the entry's tenant/request id if the journalled
+// request body isn't available, since TearDown only reads StepsCompleted,
+// the GUID, f.Request.Extra["account"] and the request id (which falls back
+// to f.Request.ApplicationType).
+func (a *AmazonProvider) tearDownEntry(entry journal.Entry) []error {
+	f, err := resumeForge(entry)
+	if err != nil {
+		f = &superkey.ForgedApplication{
+			StepsCompleted: entry.StepsCompleted,
+			Request: &superkey.CreateRequest{
+				ApplicationType: entry.RequestID,
+				Extra:           map[string]string{"account": entry.Tenant},
+			},
+			GUID: entry.GUID,
+		}
+	}
+
+	return a.TearDown(f)
 }
 
 // ForgeApplication transforms a superkey request with the amazon provider into a list
@@ -28,7 +376,42 @@ func (a *AmazonProvider) ForgeApplication(request *superkey.CreateRequest) (*sup
 		GUID:           generateGUID(),
 	}
 
+	return a.forge(f)
+}
+
+// forge runs every step of f.Request.SuperKeySteps that isn't already
+// present in f.StepsCompleted, so it doubles as both the fresh-forge path
+// (f.StepsCompleted starts empty) and the ResumeContinue replay path
+// (f.StepsCompleted is pre-seeded from the journal).
+func (a *AmazonProvider) forge(f *superkey.ForgedApplication) (*superkey.ForgedApplication, error) {
+	request := f.Request
+
+	// Lint every policy document up front, before any AWS resource has been
+	// created, so an over-broad policy never leaves behind a half-forged
+	// application for TearDown to clean up.
+	for _, step := range request.SuperKeySteps {
+		if step.Name != "policy" {
+			continue
+		}
+
+		if err := policylint.Lint(step.Payload, f.Request.ApplicationType, a.WildcardActionAllowlist, a.ActionAllowlist); err != nil {
+			l.Log.Errorf("Policy for request %v rejected by policylint: %v", f.Request, err)
+			return f, err
+		}
+	}
+
 	for _, step := range request.SuperKeySteps {
+		// assume_role_credentials is never skipped on resume: the journal
+		// only ever holds a redacted copy of the secret fields, and any
+		// credential from before the restart may already be close to
+		// expiring anyway, so it's always worth re-issuing.
+		if step.Name != "assume_role_credentials" {
+			if _, done := f.StepsCompleted[step.Name]; done {
+				l.Log.Infof("Step %v already completed for request %v, skipping on resume", step.Name, f.GUID)
+				continue
+			}
+		}
+
 		switch step.Name {
 		case "s3":
 			name := fmt.Sprintf("%v-bucket-%v", getShortName(f.Request.ApplicationType), f.GUID)
@@ -40,9 +423,83 @@ func (a *AmazonProvider) ForgeApplication(request *superkey.CreateRequest) (*sup
 				return f, err
 			}
 
-			f.MarkCompleted("s3", map[string]string{"output": name})
+			if err := a.Client.TagResource(name, resourceTags(f)); err != nil {
+				l.Log.Warnf("Failed to tag S3 bucket %v: %v", name, err)
+			}
+
+			a.markCompleted(f, "s3", map[string]string{"output": name})
 			l.Log.Infof("Successfully created S3 bucket %v", name)
 
+		case "bucket_encryption":
+			bucket := f.StepsCompleted["s3"]["output"]
+			payload := substiteInPayload(step.Payload, f, step.Substitutions)
+			l.Log.Infof("Enabling default encryption on bucket %v", bucket)
+
+			err := a.Client.PutBucketEncryption(bucket, payload)
+			if err != nil {
+				l.Log.Errorf("Failed to enable encryption on bucket %v, rolling back superkey request %v", bucket, f.Request)
+				return f, err
+			}
+
+			a.markCompleted(f, "bucket_encryption", map[string]string{"output": bucket})
+			l.Log.Infof("Successfully enabled encryption on bucket %v", bucket)
+
+		case "bucket_public_access_block":
+			bucket := f.StepsCompleted["s3"]["output"]
+			payload := substiteInPayload(step.Payload, f, step.Substitutions)
+			l.Log.Infof("Blocking public access on bucket %v", bucket)
+
+			err := a.Client.PutBucketPublicAccessBlock(bucket, payload)
+			if err != nil {
+				l.Log.Errorf("Failed to block public access on bucket %v, rolling back superkey request %v", bucket, f.Request)
+				return f, err
+			}
+
+			a.markCompleted(f, "bucket_public_access_block", map[string]string{"output": bucket})
+			l.Log.Infof("Successfully blocked public access on bucket %v", bucket)
+
+		case "bucket_policy":
+			bucket := f.StepsCompleted["s3"]["output"]
+			payload := substiteInPayload(step.Payload, f, step.Substitutions)
+			l.Log.Infof("Applying bucket policy to bucket %v", bucket)
+
+			err := a.Client.PutBucketPolicy(bucket, payload)
+			if err != nil {
+				l.Log.Errorf("Failed to apply bucket policy to bucket %v, rolling back superkey request %v", bucket, f.Request)
+				return f, err
+			}
+
+			a.markCompleted(f, "bucket_policy", map[string]string{"output": bucket})
+			l.Log.Infof("Successfully applied bucket policy to bucket %v", bucket)
+
+		case "bucket_lifecycle":
+			bucket := f.StepsCompleted["s3"]["output"]
+			payload := substiteInPayload(step.Payload, f, step.Substitutions)
+			l.Log.Infof("Installing lifecycle configuration on bucket %v", bucket)
+
+			err := a.Client.PutBucketLifecycle(bucket, payload)
+			if err != nil {
+				l.Log.Errorf("Failed to install lifecycle configuration on bucket %v, rolling back superkey request %v", bucket, f.Request)
+				return f, err
+			}
+
+			a.markCompleted(f, "bucket_lifecycle", map[string]string{"output": bucket})
+			l.Log.Infof("Successfully installed lifecycle configuration on bucket %v", bucket)
+
+		case "bucket_versioning":
+			bucket := f.StepsCompleted["s3"]["output"]
+			payload := substiteInPayload(step.Payload, f, step.Substitutions)
+			l.Log.Infof("Enabling versioning on bucket %v", bucket)
+
+			err := a.Client.PutBucketVersioning(bucket, payload)
+			if err != nil {
+				l.Log.Errorf("Failed to enable versioning on bucket %v, rolling back superkey request %v", bucket, f.Request)
+				return f, err
+			}
+
+			a.markCompleted(f, "bucket_versioning", map[string]string{"output": bucket})
+			l.Log.Infof("Successfully enabled versioning on bucket %v", bucket)
+
 		case "policy":
 			name := fmt.Sprintf("%v-policy-%v", getShortName(f.Request.ApplicationType), f.GUID)
 			payload := substiteInPayload(step.Payload, f, step.Substitutions)
@@ -54,7 +511,11 @@ func (a *AmazonProvider) ForgeApplication(request *superkey.CreateRequest) (*sup
 				return f, err
 			}
 
-			f.MarkCompleted("policy", map[string]string{"output": *arn})
+			if err := a.Client.TagResource(*arn, resourceTags(f)); err != nil {
+				l.Log.Warnf("Failed to tag policy %v: %v", *arn, err)
+			}
+
+			a.markCompleted(f, "policy", map[string]string{"output": *arn})
 			l.Log.Infof("Successfully created policy %v", name)
 
 		case "role":
@@ -68,10 +529,19 @@ func (a *AmazonProvider) ForgeApplication(request *superkey.CreateRequest) (*sup
 				return f, err
 			}
 
+			if err := a.Client.TagResource(*roleArn, resourceTags(f)); err != nil {
+				l.Log.Warnf("Failed to tag role %v: %v", *roleArn, err)
+			}
+
 			// Store the Role ARN since that is what we need to return for the Authentication object.
-			f.MarkCompleted("role", map[string]string{"output": name, "arn": *roleArn})
+			a.markCompleted(f, "role", map[string]string{"output": name, "arn": *roleArn})
 			l.Log.Infof("Successfully created role %v", name)
 
+			if err := a.attachPermissionsBoundary(f, name); err != nil {
+				l.Log.Errorf("Failed to attach permissions boundary to role %v, rolling back superkey request %v", name, f.Request)
+				return f, err
+			}
+
 		case "bind_role":
 			roleName := f.StepsCompleted["role"]["output"]
 			policyArn := f.StepsCompleted["policy"]["output"]
@@ -83,9 +553,101 @@ func (a *AmazonProvider) ForgeApplication(request *superkey.CreateRequest) (*sup
 				return f, err
 			}
 
-			f.MarkCompleted("bind_role", map[string]string{})
+			a.markCompleted(f, "bind_role", map[string]string{})
 			l.Log.Infof("Successfully bound role %v to policy %v", roleName, policyArn)
 
+		case "oidc_trust":
+			cluster := f.Request.Extra["oidc_cluster"]
+			namespace := f.Request.Extra["oidc_namespace"]
+			serviceAccount := f.Request.Extra["oidc_service_account"]
+			l.Log.Infof("Ensuring OIDC provider for cluster %v", cluster)
+
+			providerArn, err := a.Client.EnsureOIDCProvider(cluster, f.Request.Extra["oidc_issuer_url"])
+			if err != nil {
+				l.Log.Errorf("Failed to ensure OIDC provider for cluster %v, rolling back superkey request %v", cluster, f.Request)
+				return f, err
+			}
+
+			// Record provider_arn before building the trust document, since
+			// substiteInPayload's `oidc_provider_arn` token reads it back out
+			// of StepsCompleted.
+			a.markCompleted(f, "oidc_trust", map[string]string{"provider_arn": providerArn})
+			trustPayload := substiteInPayload(step.Payload, f, step.Substitutions)
+
+			if existing := f.StepsCompleted["role"]; existing != nil {
+				// A `role` step already ran - just swap in the federated trust policy.
+				err = a.Client.UpdateAssumeRolePolicy(existing["output"], trustPayload)
+			} else {
+				// No `role` step in this request - mint one with the federated trust attached.
+				name := fmt.Sprintf("%v-role-%v", getShortName(f.Request.ApplicationType), f.GUID)
+				var roleArn *string
+				roleArn, err = a.Client.CreateRole(name, trustPayload)
+				if err == nil {
+					if tagErr := a.Client.TagResource(*roleArn, resourceTags(f)); tagErr != nil {
+						l.Log.Warnf("Failed to tag role %v: %v", *roleArn, tagErr)
+					}
+					a.markCompleted(f, "role", map[string]string{"output": name, "arn": *roleArn})
+					err = a.attachPermissionsBoundary(f, name)
+				}
+			}
+
+			if err != nil {
+				l.Log.Errorf("Failed to set up OIDC trust for namespace %v/%v, rolling back superkey request %v", namespace, serviceAccount, f.Request)
+				return f, err
+			}
+
+			// Re-record with the full set of fields now that the trust is in
+			// place - MarkCompleted replaces the step's entry wholesale.
+			a.markCompleted(f, "oidc_trust", map[string]string{
+				"provider_arn": providerArn,
+				"cluster":      cluster,
+				"sub":          fmt.Sprintf("system:serviceaccount:%v:%v", namespace, serviceAccount),
+			})
+			l.Log.Infof("Successfully set up OIDC trust for %v on cluster %v", serviceAccount, cluster)
+
+		case "assume_role_credentials":
+			roleArn := f.StepsCompleted["role"]["arn"]
+			sessionName := fmt.Sprintf("%v-session-%v", getShortName(f.Request.ApplicationType), f.GUID)
+			leaseTTL := a.resolveLeaseTTL(f, roleArn)
+			l.Log.Infof("Assuming role %v for short-lived credentials (ttl %v)", roleArn, leaseTTL)
+
+			creds, err := a.Client.AssumeRole(roleArn, sessionName, leaseTTL)
+			if err != nil {
+				l.Log.Errorf("Failed to assume role %v, rolling back superkey request %v", roleArn, f.Request)
+				return f, err
+			}
+
+			a.markCompleted(f, "assume_role_credentials", map[string]string{
+				"access_key_id":     creds.AccessKeyID,
+				"secret_access_key": creds.SecretAccessKey,
+				"session_token":     creds.SessionToken,
+				"expiration":        creds.Expiration.Format(time.RFC3339),
+			})
+
+			if a.Leases != nil {
+				tenant := f.Request.Extra["account"]
+				requestID := requestIdentifier(f)
+				guid := f.GUID
+
+				a.Leases.Track(guid, lease.Renewer{
+					RoleArn:     roleArn,
+					SessionName: sessionName,
+					TTL:         leaseTTL,
+					AssumeFunc:  a.Client.AssumeRole,
+					Publisher:   a.CredentialPublisher,
+					// Runs on the renewal goroutine - it must never touch
+					// f.StepsCompleted, since the main goroutine can be
+					// reading/writing it concurrently (e.g. from TearDown).
+					// Only the Journal, which owns its own synchronization,
+					// is updated here.
+					OnRotate: func(rotated *amazon.STSCredentials) {
+						a.journalRotatedCredentials(tenant, requestID, guid, rotated)
+					},
+				})
+			}
+
+			l.Log.Infof("Successfully assumed role %v, lease tracked under %v", roleArn, f.GUID)
+
 		default:
 			l.Log.Infof("%v not implemented yet!", step.Name)
 		}
@@ -94,8 +656,36 @@ func (a *AmazonProvider) ForgeApplication(request *superkey.CreateRequest) (*sup
 	// Set the username to the role ARN since that is what is needed for this provider.
 	username := f.StepsCompleted["role"]["arn"]
 	appType := path.Base(f.Request.ApplicationType)
+
+	// When `assume_role_credentials` ran, surface the short-lived STS
+	// credentials on the forged payload alongside the role ARN so Sources
+	// can hand them straight to the client instead of the long-lived role.
+	var sts *superkey.STSCredentials
+	if creds := f.StepsCompleted["assume_role_credentials"]; creds != nil {
+		expiration, _ := time.Parse(time.RFC3339, creds["expiration"])
+		sts = &superkey.STSCredentials{
+			AccessKeyID:     creds["access_key_id"],
+			SecretAccessKey: creds["secret_access_key"],
+			SessionToken:    creds["session_token"],
+			Expiration:      expiration,
+		}
+	}
+
 	// Create the payload struct
-	f.CreatePayload(&username, nil, &appType)
+	f.CreatePayload(&username, nil, &appType, sts)
+
+	if a.Journal != nil {
+		err := a.Journal.Put(journal.Entry{
+			Tenant:         f.Request.Extra["account"],
+			RequestID:      requestIdentifier(f),
+			GUID:           f.GUID,
+			Status:         journal.StatusComplete,
+			StepsCompleted: redactForJournal(f.StepsCompleted),
+		})
+		if err != nil {
+			l.Log.Warnf("Failed to journal completion of request %v: %v", f.GUID, err)
+		}
+	}
 
 	return f, nil
 }
@@ -120,9 +710,21 @@ func substiteInPayload(payload string, f *superkey.ForgedApplication, substituti
 		case "get_account":
 			accountNumber := f.Request.Extra["account"]
 			payload = strings.Replace(payload, name, accountNumber, -1)
-		case "s3":
+		case "s3", "bucket":
 			s3name := f.StepsCompleted["s3"]["output"]
 			payload = strings.Replace(payload, name, s3name, -1)
+		case "role_arn":
+			roleArn := f.StepsCompleted["role"]["arn"]
+			payload = strings.Replace(payload, name, roleArn, -1)
+		case "oidc_provider_arn":
+			providerArn := f.StepsCompleted["oidc_trust"]["provider_arn"]
+			payload = strings.Replace(payload, name, providerArn, -1)
+		case "oidc_sub":
+			namespace := f.Request.Extra["oidc_namespace"]
+			serviceAccount := f.Request.Extra["oidc_service_account"]
+			payload = strings.Replace(payload, name, fmt.Sprintf("system:serviceaccount:%v:%v", namespace, serviceAccount), -1)
+		case "oidc_aud":
+			payload = strings.Replace(payload, name, "sts.amazonaws.com", -1)
 		}
 	}
 
@@ -137,6 +739,14 @@ func substiteInPayload(payload string, f *superkey.ForgedApplication, substituti
 func (a *AmazonProvider) TearDown(f *superkey.ForgedApplication) []error {
 	errors := make([]error, 0)
 
+	// -----------------
+	// stop renewing any STS lease before touching the role it was issued from.
+	// -----------------
+	if f.StepsCompleted["assume_role_credentials"] != nil && a.Leases != nil {
+		a.Leases.Cancel(f.GUID)
+		l.Log.Infof("Cancelled STS lease for %v", f.GUID)
+	}
+
 	// -----------------
 	// unbind the role first (if it happened) so we can cleanly delete the policy and the role.
 	// -----------------
@@ -168,6 +778,18 @@ func (a *AmazonProvider) TearDown(f *superkey.ForgedApplication) []error {
 		l.Log.Infof("Destroyed policy %v", policyArn)
 	}
 
+	if f.StepsCompleted["attach_boundary"] != nil {
+		roleName := f.StepsCompleted["role"]["output"]
+
+		err := a.Client.DeleteRolePermissionsBoundary(roleName)
+		if err != nil {
+			l.Log.Warnf("Failed to detach permissions boundary from role %v", roleName)
+			errors = append(errors, err)
+		}
+
+		l.Log.Infof("Detached permissions boundary from role %v", roleName)
+	}
+
 	if f.StepsCompleted["role"] != nil {
 		roleName := f.StepsCompleted["role"]["output"]
 
@@ -180,6 +802,85 @@ func (a *AmazonProvider) TearDown(f *superkey.ForgedApplication) []error {
 		l.Log.Infof("Destroyed role %v", roleName)
 	}
 
+	// -----------------
+	// release our reference to the OIDC provider - it's only actually deleted
+	// once no other forged application is still referencing it.
+	// -----------------
+	if f.StepsCompleted["oidc_trust"] != nil {
+		providerArn := f.StepsCompleted["oidc_trust"]["provider_arn"]
+
+		err := a.Client.ReleaseOIDCProvider(providerArn)
+		if err != nil {
+			l.Log.Warnf("Failed to release OIDC provider %v", providerArn)
+			errors = append(errors, err)
+		}
+
+		l.Log.Infof("Released OIDC provider %v", providerArn)
+	}
+
+	// -----------------
+	// unwind the bucket hardening steps before the bucket itself goes away.
+	// -----------------
+	if f.StepsCompleted["bucket_versioning"] != nil {
+		bucket := f.StepsCompleted["s3"]["output"]
+
+		err := a.Client.SuspendBucketVersioning(bucket)
+		if err != nil {
+			l.Log.Warnf("Failed to suspend versioning on bucket %v", bucket)
+			errors = append(errors, err)
+		}
+
+		l.Log.Infof("Suspended versioning on bucket %v", bucket)
+	}
+
+	if f.StepsCompleted["bucket_lifecycle"] != nil {
+		bucket := f.StepsCompleted["s3"]["output"]
+
+		err := a.Client.DeleteBucketLifecycle(bucket)
+		if err != nil {
+			l.Log.Warnf("Failed to remove lifecycle configuration from bucket %v", bucket)
+			errors = append(errors, err)
+		}
+
+		l.Log.Infof("Removed lifecycle configuration from bucket %v", bucket)
+	}
+
+	if f.StepsCompleted["bucket_policy"] != nil {
+		bucket := f.StepsCompleted["s3"]["output"]
+
+		err := a.Client.DeleteBucketPolicy(bucket)
+		if err != nil {
+			l.Log.Warnf("Failed to remove bucket policy from bucket %v", bucket)
+			errors = append(errors, err)
+		}
+
+		l.Log.Infof("Removed bucket policy from bucket %v", bucket)
+	}
+
+	if f.StepsCompleted["bucket_public_access_block"] != nil {
+		bucket := f.StepsCompleted["s3"]["output"]
+
+		err := a.Client.DeleteBucketPublicAccessBlock(bucket)
+		if err != nil {
+			l.Log.Warnf("Failed to remove public access block from bucket %v", bucket)
+			errors = append(errors, err)
+		}
+
+		l.Log.Infof("Removed public access block from bucket %v", bucket)
+	}
+
+	if f.StepsCompleted["bucket_encryption"] != nil {
+		bucket := f.StepsCompleted["s3"]["output"]
+
+		err := a.Client.DeleteBucketEncryption(bucket)
+		if err != nil {
+			l.Log.Warnf("Failed to remove encryption configuration from bucket %v", bucket)
+			errors = append(errors, err)
+		}
+
+		l.Log.Infof("Removed encryption configuration from bucket %v", bucket)
+	}
+
 	// -----------------
 	// s3 bucket can probably be deleted earlier, but leave it to last just in case
 	// other things depend on it.
@@ -196,5 +897,27 @@ func (a *AmazonProvider) TearDown(f *superkey.ForgedApplication) []error {
 		l.Log.Infof("Destroyed s3 bucket %v", bucket)
 	}
 
+	if a.Journal != nil {
+		tenant := f.Request.Extra["account"]
+		if len(errors) == 0 {
+			if err := a.Journal.Delete(tenant, f.GUID); err != nil {
+				l.Log.Warnf("Failed to remove journal entry for request %v: %v", f.GUID, err)
+			}
+		} else {
+			// Leave the entry behind as `torn_down` with errors so Reconcile
+			// can retry the leftovers instead of silently losing them.
+			err := a.Journal.Put(journal.Entry{
+				Tenant:         tenant,
+				RequestID:      requestIdentifier(f),
+				GUID:           f.GUID,
+				Status:         journal.StatusTornDown,
+				StepsCompleted: redactForJournal(f.StepsCompleted),
+			})
+			if err != nil {
+				l.Log.Warnf("Failed to journal teardown of request %v: %v", f.GUID, err)
+			}
+		}
+	}
+
 	return errors
 }
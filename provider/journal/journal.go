@@ -0,0 +1,129 @@
+// Package journal persists the steps a superkey forge operation has
+// completed so that a worker crash between two AWS calls doesn't leak the
+// resources already created. Every step `AmazonProvider.ForgeApplication`
+// completes is recorded here before the worker moves on to the next one, and
+// on restart the worker replays any entry still marked `in_progress`.
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// ResumePolicy controls what a replayed `in_progress` entry does on worker
+// startup.
+type ResumePolicy string
+
+const (
+	// ResumeTearDown rolls back whatever steps completed. This is the safe
+	// default when a request carries no explicit resume_policy.
+	ResumeTearDown ResumePolicy = "tear_down"
+	// ResumeContinue picks the forge back up from its last completed step,
+	// using the journalled copy of the original request.
+	ResumeContinue ResumePolicy = "resume"
+)
+
+// Status is the lifecycle state of a single journalled forge request.
+type Status string
+
+const (
+	// StatusInProgress means at least one step has completed but the forge
+	// has not finished - on restart it should be resumed or torn down.
+	StatusInProgress Status = "in_progress"
+	// StatusComplete means CreatePayload ran and the request is done.
+	StatusComplete Status = "complete"
+	// StatusTornDown means TearDown ran for this GUID but failed to remove
+	// one or more resources. A teardown without errors deletes the entry
+	// instead.
+	StatusTornDown Status = "torn_down"
+)
+
+// Entry is a single journalled forge request.
+type Entry struct {
+	Tenant         string
+	RequestID      string
+	GUID           string
+	Status         Status
+	StepsCompleted map[string]map[string]string
+	// ResumePolicy says what to do with this entry if it is still
+	// in_progress when the worker restarts. Empty means ResumeTearDown.
+	ResumePolicy ResumePolicy
+	// Request is the original superkey.CreateRequest that started this
+	// forge, serialized to JSON so ResumeContinue can pick it back up
+	// without needing the originating Kafka message still around.
+	Request json.RawMessage
+}
+
+// Journal is the persistence contract `ForgeApplication` writes to after
+// every step and `TearDown` writes to once it finishes, and that the worker
+// replays on startup.
+type Journal interface {
+	// Put upserts the entry for (tenant, guid).
+	Put(entry Entry) error
+	// Get fetches the entry for (tenant, guid), or ok=false if none exists.
+	Get(tenant, guid string) (entry Entry, ok bool, err error)
+	// InProgress returns every entry not yet in StatusComplete or
+	// StatusTornDown, for replay on worker startup.
+	InProgress() ([]Entry, error)
+	// Delete removes the entry for (tenant, guid), once TearDown has
+	// finished and there's nothing left to reconcile.
+	Delete(tenant, guid string) error
+}
+
+// key uniquely identifies a journalled entry.
+func key(tenant, guid string) string {
+	return fmt.Sprintf("%v/%v", tenant, guid)
+}
+
+// InMemory is a Journal backed by a plain map, useful for local development
+// and for tests. It does not survive a process restart, so it offers no
+// crash-recovery guarantee on its own - Postgres does.
+type InMemory struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewInMemory returns an empty in-memory Journal.
+func NewInMemory() *InMemory {
+	return &InMemory{entries: make(map[string]Entry)}
+}
+
+// Put implements Journal.
+func (m *InMemory) Put(entry Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key(entry.Tenant, entry.GUID)] = entry
+	return nil
+}
+
+// Get implements Journal.
+func (m *InMemory) Get(tenant, guid string) (Entry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key(tenant, guid)]
+	return entry, ok, nil
+}
+
+// InProgress implements Journal.
+func (m *InMemory) InProgress() ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := make([]Entry, 0)
+	for _, entry := range m.entries {
+		if entry.Status == StatusInProgress {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// Delete implements Journal.
+func (m *InMemory) Delete(tenant, guid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key(tenant, guid))
+	return nil
+}
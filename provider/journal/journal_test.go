@@ -0,0 +1,74 @@
+package journal
+
+import "testing"
+
+func TestInMemoryPutGetRoundTrip(t *testing.T) {
+	j := NewInMemory()
+
+	entry := Entry{
+		Tenant:         "tenant-1",
+		RequestID:      "req-1",
+		GUID:           "guid-1",
+		Status:         StatusInProgress,
+		StepsCompleted: map[string]map[string]string{"s3": {"output": "some-bucket"}},
+		ResumePolicy:   ResumeContinue,
+		Request:        []byte(`{"application_type": "some-app"}`),
+	}
+
+	if err := j.Put(entry); err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+
+	got, ok, err := j.Get("tenant-1", "guid-1")
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected the entry to be found")
+	}
+	if got.RequestID != entry.RequestID || got.ResumePolicy != entry.ResumePolicy {
+		t.Errorf("Get returned %+v, want %+v", got, entry)
+	}
+}
+
+func TestInMemoryGetMissingEntry(t *testing.T) {
+	j := NewInMemory()
+
+	_, ok, err := j.Get("tenant-1", "missing-guid")
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if ok {
+		t.Error("expected no entry to be found")
+	}
+}
+
+func TestInMemoryInProgressOnlyReturnsInProgressEntries(t *testing.T) {
+	j := NewInMemory()
+
+	j.Put(Entry{Tenant: "t", GUID: "in-progress", Status: StatusInProgress})
+	j.Put(Entry{Tenant: "t", GUID: "complete", Status: StatusComplete})
+	j.Put(Entry{Tenant: "t", GUID: "torn-down", Status: StatusTornDown})
+
+	entries, err := j.InProgress()
+	if err != nil {
+		t.Fatalf("InProgress returned an error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].GUID != "in-progress" {
+		t.Errorf("InProgress returned %+v, want only the in_progress entry", entries)
+	}
+}
+
+func TestInMemoryDelete(t *testing.T) {
+	j := NewInMemory()
+	j.Put(Entry{Tenant: "t", GUID: "guid-1", Status: StatusInProgress})
+
+	if err := j.Delete("t", "guid-1"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+
+	_, ok, _ := j.Get("t", "guid-1")
+	if ok {
+		t.Error("expected the entry to be gone after Delete")
+	}
+}
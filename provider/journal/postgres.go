@@ -0,0 +1,125 @@
+package journal
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// Postgres is a Journal backed by a `superkey_journal` table, so that
+// in-progress forge state survives a worker restart.
+//
+//	CREATE TABLE superkey_journal (
+//	    tenant          text NOT NULL,
+//	    guid            text NOT NULL,
+//	    request_id      text NOT NULL,
+//	    status          text NOT NULL,
+//	    steps_completed jsonb NOT NULL DEFAULT '{}',
+//	    resume_policy   text NOT NULL DEFAULT 'tear_down',
+//	    request         jsonb,
+//	    PRIMARY KEY (tenant, guid)
+//	);
+type Postgres struct {
+	db *sql.DB
+}
+
+// NewPostgres wraps an already-connected *sql.DB as a Journal.
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// Put implements Journal.
+func (p *Postgres) Put(entry Entry) error {
+	steps, err := json.Marshal(entry.StepsCompleted)
+	if err != nil {
+		return err
+	}
+
+	resumePolicy := entry.ResumePolicy
+	if resumePolicy == "" {
+		resumePolicy = ResumeTearDown
+	}
+
+	_, err = p.db.Exec(`
+		INSERT INTO superkey_journal (tenant, guid, request_id, status, steps_completed, resume_policy, request)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (tenant, guid) DO UPDATE
+		SET request_id = $3, status = $4, steps_completed = $5, resume_policy = $6, request = $7
+	`, entry.Tenant, entry.GUID, entry.RequestID, entry.Status, steps, resumePolicy, nullableJSON(entry.Request))
+
+	return err
+}
+
+// Get implements Journal.
+func (p *Postgres) Get(tenant, guid string) (Entry, bool, error) {
+	row := p.db.QueryRow(`
+		SELECT tenant, guid, request_id, status, steps_completed, resume_policy, request
+		FROM superkey_journal
+		WHERE tenant = $1 AND guid = $2
+	`, tenant, guid)
+
+	entry, err := scanEntry(row.Scan)
+	if err == sql.ErrNoRows {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	return entry, true, nil
+}
+
+// InProgress implements Journal.
+func (p *Postgres) InProgress() ([]Entry, error) {
+	rows, err := p.db.Query(`
+		SELECT tenant, guid, request_id, status, steps_completed, resume_policy, request
+		FROM superkey_journal
+		WHERE status = $1
+	`, StatusInProgress)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		entry, err := scanEntry(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Delete implements Journal.
+func (p *Postgres) Delete(tenant, guid string) error {
+	_, err := p.db.Exec(`DELETE FROM superkey_journal WHERE tenant = $1 AND guid = $2`, tenant, guid)
+	return err
+}
+
+// nullableJSON turns an empty/nil payload into SQL NULL so the `request`
+// column (which has no NOT NULL constraint) round-trips cleanly.
+func nullableJSON(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return []byte(raw)
+}
+
+func scanEntry(scan func(...interface{}) error) (Entry, error) {
+	var entry Entry
+	var steps []byte
+	var request []byte
+
+	if err := scan(&entry.Tenant, &entry.GUID, &entry.RequestID, &entry.Status, &steps, &entry.ResumePolicy, &request); err != nil {
+		return Entry{}, err
+	}
+
+	if err := json.Unmarshal(steps, &entry.StepsCompleted); err != nil {
+		return Entry{}, err
+	}
+	entry.Request = request
+
+	return entry, nil
+}
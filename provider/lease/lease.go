@@ -0,0 +1,125 @@
+// Package lease tracks short-lived STS credentials that AmazonProvider hands
+// out for `assume_role_credentials` steps, and re-assumes the underlying role
+// before each lease expires so the caller always has a valid set of keys.
+package lease
+
+import (
+	"sync"
+	"time"
+
+	"github.com/redhatinsights/sources-superkey-worker/amazon"
+	l "github.com/redhatinsights/sources-superkey-worker/logger"
+)
+
+// renewBuffer is how far ahead of expiry a lease is re-assumed, so callers
+// never observe a credential that is about to expire mid-request.
+const renewBuffer = 5 * time.Minute
+
+// Publisher hands rotated credentials back to Sources. It is implemented by
+// the Kafka producer the worker already consumes superkey requests from, so
+// a rotation looks like any other outbound message on that same topic.
+type Publisher interface {
+	Publish(guid string, creds *amazon.STSCredentials) error
+}
+
+// Renewer describes everything the Manager needs to keep a single STS lease
+// alive for the lifetime of a forged application.
+type Renewer struct {
+	RoleArn     string
+	SessionName string
+	// TTL is the requested session duration, capped by the role's own
+	// MaxSessionDuration on the AWS side.
+	TTL time.Duration
+	// AssumeFunc performs the actual sts:AssumeRole call.
+	AssumeFunc func(roleArn, sessionName string, ttl time.Duration) (*amazon.STSCredentials, error)
+	// Publisher, when set, is sent every rotated credential so Sources can
+	// update the authentication record. OnRotate below receives the same
+	// credentials, but must not be used to mutate state shared with the
+	// request's own goroutine.
+	Publisher Publisher
+	// OnRotate is invoked with the freshly assumed credentials every time
+	// the lease is renewed, so the caller can persist them (e.g. to the
+	// journal). It runs on the renewal goroutine, so it must only touch
+	// state that is safe for concurrent access - never the live
+	// superkey.ForgedApplication.StepsCompleted map.
+	OnRotate func(*amazon.STSCredentials)
+}
+
+// Manager tracks in-flight leases keyed by request GUID and renews them in
+// the background until they are cancelled.
+type Manager struct {
+	mu     sync.Mutex
+	leases map[string]chan struct{}
+}
+
+// NewManager returns an empty lease Manager.
+func NewManager() *Manager {
+	return &Manager{leases: make(map[string]chan struct{})}
+}
+
+// Track starts a background goroutine that re-assumes r.RoleArn shortly
+// before each issued lease expires, calling r.OnRotate with the new
+// credentials every time. It replaces any lease already tracked under guid.
+func (m *Manager) Track(guid string, r Renewer) {
+	m.Cancel(guid)
+
+	stop := make(chan struct{})
+	m.mu.Lock()
+	m.leases[guid] = stop
+	m.mu.Unlock()
+
+	go m.run(guid, r, stop)
+}
+
+// Cancel stops renewing the lease tracked under guid, if any. Safe to call
+// even if no lease is tracked.
+func (m *Manager) Cancel(guid string) {
+	m.mu.Lock()
+	stop, ok := m.leases[guid]
+	if ok {
+		delete(m.leases, guid)
+	}
+	m.mu.Unlock()
+
+	if ok {
+		close(stop)
+	}
+}
+
+func (m *Manager) run(guid string, r Renewer, stop chan struct{}) {
+	ttl := r.TTL
+	if ttl <= renewBuffer {
+		ttl = renewBuffer * 2
+	}
+
+	timer := time.NewTimer(ttl - renewBuffer)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+			creds, err := r.AssumeFunc(r.RoleArn, r.SessionName, r.TTL)
+			if err != nil {
+				l.Log.Errorf("Failed to renew STS lease %v for role %v: %v", guid, r.RoleArn, err)
+				timer.Reset(renewBuffer)
+				continue
+			}
+
+			l.Log.Infof("Renewed STS lease %v for role %v", guid, r.RoleArn)
+
+			if r.Publisher != nil {
+				if err := r.Publisher.Publish(guid, creds); err != nil {
+					l.Log.Errorf("Failed to publish rotated STS lease %v for role %v: %v", guid, r.RoleArn, err)
+				}
+			}
+
+			if r.OnRotate != nil {
+				r.OnRotate(creds)
+			}
+
+			timer.Reset(ttl - renewBuffer)
+		}
+	}
+}
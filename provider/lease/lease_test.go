@@ -0,0 +1,88 @@
+package lease
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redhatinsights/sources-superkey-worker/amazon"
+)
+
+func TestManagerTrackRenewsAndPublishesBeforeExpiry(t *testing.T) {
+	m := NewManager()
+	defer m.Cancel("guid-1")
+
+	var mu sync.Mutex
+	var published *amazon.STSCredentials
+	var rotated *amazon.STSCredentials
+	renewed := make(chan struct{}, 1)
+
+	m.Track("guid-1", Renewer{
+		RoleArn:     "arn:aws:iam::123456789012:role/some-role",
+		SessionName: "some-session",
+		// TTL is kept just above renewBuffer so the first renewal fires
+		// almost immediately, instead of waiting out a real lease.
+		TTL: renewBuffer + 20*time.Millisecond,
+		AssumeFunc: func(roleArn, sessionName string, ttl time.Duration) (*amazon.STSCredentials, error) {
+			return &amazon.STSCredentials{AccessKeyID: "renewed-key"}, nil
+		},
+		Publisher: publisherFunc(func(guid string, creds *amazon.STSCredentials) error {
+			mu.Lock()
+			published = creds
+			mu.Unlock()
+			return nil
+		}),
+		OnRotate: func(creds *amazon.STSCredentials) {
+			mu.Lock()
+			rotated = creds
+			mu.Unlock()
+			renewed <- struct{}{}
+		},
+	})
+
+	select {
+	case <-renewed:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the lease to renew")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if published == nil || published.AccessKeyID != "renewed-key" {
+		t.Errorf("expected Publisher to receive the renewed credentials, got %+v", published)
+	}
+	if rotated == nil || rotated.AccessKeyID != "renewed-key" {
+		t.Errorf("expected OnRotate to receive the renewed credentials, got %+v", rotated)
+	}
+}
+
+func TestManagerCancelStopsRenewal(t *testing.T) {
+	m := NewManager()
+
+	calls := make(chan struct{}, 10)
+
+	m.Track("guid-2", Renewer{
+		RoleArn:     "arn:aws:iam::123456789012:role/some-role",
+		SessionName: "some-session",
+		TTL:         renewBuffer + 20*time.Millisecond,
+		AssumeFunc: func(roleArn, sessionName string, ttl time.Duration) (*amazon.STSCredentials, error) {
+			calls <- struct{}{}
+			return &amazon.STSCredentials{AccessKeyID: "renewed-key"}, nil
+		},
+	})
+
+	<-calls
+	m.Cancel("guid-2")
+
+	select {
+	case <-calls:
+		t.Error("expected no further renewals after Cancel")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+type publisherFunc func(guid string, creds *amazon.STSCredentials) error
+
+func (f publisherFunc) Publish(guid string, creds *amazon.STSCredentials) error {
+	return f(guid, creds)
+}
@@ -0,0 +1,147 @@
+// Package policylint is a pre-flight linter for the IAM policy documents
+// SuperKey requests ask AmazonProvider to create. It exists to catch
+// overly-broad policies before any AWS call is made, rather than after a
+// role and policy have already been created and bound together.
+package policylint
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Violation describes a single statement that failed the lint.
+type Violation struct {
+	Statement int
+	Reason    string
+}
+
+// Error is returned by Lint when one or more statements are rejected. It is
+// structured so callers can report every violation, not just the first.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("policy rejected by policylint: %d violation(s), first: statement %d: %v",
+		len(e.Violations), e.Violations[0].Statement, e.Violations[0].Reason)
+}
+
+type statement struct {
+	Effect   string      `json:"Effect"`
+	Action   interface{} `json:"Action"`
+	Resource interface{} `json:"Resource"`
+}
+
+type document struct {
+	Statement []statement `json:"Statement"`
+}
+
+// UnmarshalJSON accepts both legal shapes of the "Statement" field - IAM
+// allows a single statement object as well as an array of them - so a
+// document isn't rejected as malformed just for using the single-object
+// form.
+func (d *document) UnmarshalJSON(data []byte) error {
+	var wrapper struct {
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return err
+	}
+
+	if len(wrapper.Statement) == 0 {
+		return nil
+	}
+
+	if wrapper.Statement[0] == '[' {
+		return json.Unmarshal(wrapper.Statement, &d.Statement)
+	}
+
+	var single statement
+	if err := json.Unmarshal(wrapper.Statement, &single); err != nil {
+		return err
+	}
+	d.Statement = []statement{single}
+	return nil
+}
+
+// Lint parses an IAM policy document and rejects statements that grant
+// "Action": "*" combined with "Resource": "*" (unless the application type
+// is in wildcardAllowlist), that grant iam:* or sts:AssumeRole on "*", or
+// that grant any action outside actionAllowlist when it is non-empty.
+func Lint(payload string, appType string, wildcardAllowlist []string, actionAllowlist []string) error {
+	var doc document
+	if err := json.Unmarshal([]byte(payload), &doc); err != nil {
+		return fmt.Errorf("policylint: failed to parse policy document: %w", err)
+	}
+
+	allowWildcard := false
+	for _, allowed := range wildcardAllowlist {
+		if allowed == appType {
+			allowWildcard = true
+			break
+		}
+	}
+
+	violations := make([]Violation, 0)
+	for i, stmt := range doc.Statement {
+		if stmt.Effect != "Allow" {
+			continue
+		}
+
+		actions := stringSlice(stmt.Action)
+		resources := stringSlice(stmt.Resource)
+
+		if !allowWildcard && contains(actions, "*") && contains(resources, "*") {
+			violations = append(violations, Violation{Statement: i, Reason: `"Action": "*" combined with "Resource": "*" is not allowed`})
+			continue
+		}
+
+		if contains(resources, "*") {
+			for _, action := range actions {
+				if action == "iam:*" || action == "sts:AssumeRole" {
+					violations = append(violations, Violation{Statement: i, Reason: fmt.Sprintf("%v on \"Resource\": \"*\" is not allowed", action)})
+				}
+			}
+		}
+
+		if len(actionAllowlist) > 0 {
+			for _, action := range actions {
+				if !contains(actionAllowlist, action) {
+					violations = append(violations, Violation{Statement: i, Reason: fmt.Sprintf("action %v is not in the allowed list of actions", action)})
+				}
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+
+	return nil
+}
+
+func stringSlice(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func contains(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package policylint
+
+import "testing"
+
+func policy(statement string) string {
+	return `{"Version": "2012-10-17", "Statement": ` + statement + `}`
+}
+
+func TestLintAcceptsScopedPolicy(t *testing.T) {
+	payload := policy(`[{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::some-bucket/*"}]`)
+
+	if err := Lint(payload, "app", nil, nil); err != nil {
+		t.Errorf("expected scoped policy to be accepted, got: %v", err)
+	}
+}
+
+func TestLintRejectsFullWildcard(t *testing.T) {
+	payload := policy(`[{"Effect": "Allow", "Action": "*", "Resource": "*"}]`)
+
+	if err := Lint(payload, "app", nil, nil); err == nil {
+		t.Error("expected Action:*/Resource:* to be rejected")
+	}
+}
+
+func TestLintAllowsFullWildcardForAllowlistedAppType(t *testing.T) {
+	payload := policy(`[{"Effect": "Allow", "Action": "*", "Resource": "*"}]`)
+
+	if err := Lint(payload, "trusted-app", []string{"trusted-app"}, nil); err != nil {
+		t.Errorf("expected wildcard to be allowed for allowlisted app type, got: %v", err)
+	}
+}
+
+func TestLintRejectsIAMWildcardOnAllResources(t *testing.T) {
+	payload := policy(`[{"Effect": "Allow", "Action": "iam:*", "Resource": "*"}]`)
+
+	if err := Lint(payload, "app", nil, nil); err == nil {
+		t.Error("expected iam:* on Resource:* to be rejected")
+	}
+}
+
+func TestLintRejectsAssumeRoleOnAllResources(t *testing.T) {
+	payload := policy(`[{"Effect": "Allow", "Action": "sts:AssumeRole", "Resource": "*"}]`)
+
+	if err := Lint(payload, "app", nil, nil); err == nil {
+		t.Error("expected sts:AssumeRole on Resource:* to be rejected")
+	}
+}
+
+func TestLintRejectsActionOutsideAllowlist(t *testing.T) {
+	payload := policy(`[{"Effect": "Allow", "Action": "ec2:TerminateInstances", "Resource": "arn:aws:ec2:::instance/*"}]`)
+
+	if err := Lint(payload, "app", nil, []string{"s3:GetObject"}); err == nil {
+		t.Error("expected action outside the allowlist to be rejected")
+	}
+}
+
+func TestLintAcceptsActionInAllowlist(t *testing.T) {
+	payload := policy(`[{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::some-bucket/*"}]`)
+
+	if err := Lint(payload, "app", nil, []string{"s3:GetObject"}); err != nil {
+		t.Errorf("expected action in the allowlist to be accepted, got: %v", err)
+	}
+}
+
+func TestLintAcceptsSingleStatementObject(t *testing.T) {
+	payload := policy(`{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::some-bucket/*"}`)
+
+	if err := Lint(payload, "app", nil, nil); err != nil {
+		t.Errorf("expected single-object Statement form to parse, got: %v", err)
+	}
+}
+
+func TestLintIgnoresDenyStatements(t *testing.T) {
+	payload := policy(`[{"Effect": "Deny", "Action": "*", "Resource": "*"}]`)
+
+	if err := Lint(payload, "app", nil, nil); err != nil {
+		t.Errorf("expected Deny statements to be ignored, got: %v", err)
+	}
+}
+
+func TestLintRejectsMalformedPayload(t *testing.T) {
+	if err := Lint("not json", "app", nil, nil); err == nil {
+		t.Error("expected malformed payload to be rejected")
+	}
+}